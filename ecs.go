@@ -2,12 +2,19 @@ package ecs
 
 import (
 	"reflect"
+	"sync"
 	"unsafe"
 
 	"github.com/jabolopes/go-sparseset"
 )
 
+// typeIdMu guards typeIdGen/typeIds, since getTypeId can run its
+// first-time-seen branch from two systems Tick schedules concurrently, even
+// when neither declares the type being looked up (it may just be an
+// implementation detail of a pool operation neither system's read/write set
+// mentions).
 var (
+	typeIdMu  sync.Mutex
 	typeIdGen = 0
 	typeIds   = map[reflect.Type]int{}
 )
@@ -16,6 +23,9 @@ func getTypeId[T any]() int {
 	var t T
 	typ := reflect.TypeOf(t)
 
+	typeIdMu.Lock()
+	defer typeIdMu.Unlock()
+
 	typeId, ok := typeIds[typ]
 	if !ok {
 		typeId = typeIdGen
@@ -26,10 +36,6 @@ func getTypeId[T any]() int {
 	return typeId
 }
 
-type remover interface {
-	Remove(int)
-}
-
 // ECS is the Entity Component System.
 //
 // Several functions / methods return pointers to components. These pointers are
@@ -43,12 +49,23 @@ type remover interface {
 type ECS struct {
 	defaultPageSize int
 	nullKey         int
+	poolsMu         sync.Mutex
 	pools           map[int]unsafe.Pointer
-	removers        []remover
-	idGenerator     int
+	removers        []func(e *ECS, entity Entity)
+	generations     []uint32
+	freeList        []int
+	systems         []*System
+	stages          [][]*System
+	serializables   []*serializable
+	observers       map[int]unsafe.Pointer
 }
 
+// getPool looks up T's pool, guarding e.pools the same way initPool does:
+// concurrently scheduled systems can both be seeing T for the first time.
 func getPool[T any](e *ECS) (*sparseset.Set[T], bool) {
+	e.poolsMu.Lock()
+	defer e.poolsMu.Unlock()
+
 	set, ok := e.pools[getTypeId[T]()]
 	if !ok {
 		return nil, false
@@ -79,22 +96,83 @@ func initPool[T any](e *ECS) *sparseset.Set[T] {
 		pool = sparseset.New[T](e.defaultPageSize, e.nullKey)
 	}
 
-	e.pools[getTypeId[T]()] = unsafe.Pointer(pool)
-	e.removers = append(e.removers, pool)
+	e.poolsMu.Lock()
+	defer e.poolsMu.Unlock()
+
+	// Another goroutine may have raced us to create T's pool between our
+	// unlocked check above and taking the lock; if so, use theirs and let
+	// ours be garbage.
+	typeId := getTypeId[T]()
+	if p, ok := e.pools[typeId]; ok {
+		return (*sparseset.Set[T])(p)
+	}
+
+	e.pools[typeId] = unsafe.Pointer(pool)
+	e.removers = append(e.removers, func(e *ECS, entity Entity) {
+		index := entity.index()
+		if _, ok := pool.Get(index); !ok {
+			return
+		}
+
+		pool.Remove(index)
+		notifyRemove[T](e, entity)
+	})
 	return pool
 }
 
-// Creates a new entity and returns the entity ID.
-func (e *ECS) Add() int {
-	id := e.idGenerator
-	e.idGenerator++
-	return id
+// Creates a new entity and returns it. Add recycles the index of a
+// previously removed entity when one is available, bumping its generation
+// so that any Entity value still referring to the old occupant of that
+// index is no longer considered alive (see IsAlive).
+func (e *ECS) Add() Entity {
+	if n := len(e.freeList); n > 0 {
+		index := e.freeList[n-1]
+		e.freeList = e.freeList[:n-1]
+		return newEntity(index, e.generations[index])
+	}
+
+	index := len(e.generations)
+	e.generations = append(e.generations, 0)
+	return newEntity(index, 0)
+}
+
+// clearComponents removes every component from entity's pools and fires
+// OnRemove observers, without touching entity's identity. It's the
+// component-clearing half of Remove, reused by Init to reset an entity in
+// place without invalidating it. If entity is not alive (see IsAlive), it's
+// a no-op.
+func (e *ECS) clearComponents(entity Entity) {
+	if !IsAlive(e, entity) {
+		return
+	}
+
+	for _, remove := range e.removers {
+		remove(e, entity)
+	}
+}
+
+// Removes an entity given its Entity and removes all of its components. If
+// entity is not alive (see IsAlive), it's a no-op.
+func (e *ECS) Remove(entity Entity) {
+	if !IsAlive(e, entity) {
+		return
+	}
+
+	e.clearComponents(entity)
+
+	index := entity.index()
+	e.generations[index]++
+	e.freeList = append(e.freeList, index)
 }
 
-// Removes an entity given its ID and removes all of its components.
-func (e *ECS) Remove(entityId int) {
-	for _, remover := range e.removers {
-		remover.Remove(entityId)
+// ensureGeneration grows e.generations so that index is valid, leaving any
+// newly created slots at generation 0. Diff/Apply can reference a pool index
+// that e hasn't allocated itself (e.g. Apply is replaying a delta produced
+// by a peer that's further ahead), so restoring a component at that index
+// needs this before it can mint a matching Entity.
+func (e *ECS) ensureGeneration(index int) {
+	for len(e.generations) <= index {
+		e.generations = append(e.generations, 0)
 	}
 }
 
@@ -103,74 +181,89 @@ func New() *ECS {
 	return &ECS{
 		4096,                     /* defaultPageSize */
 		1 << 20,                  /* nullKey */
+		sync.Mutex{},             /* poolsMu */
 		map[int]unsafe.Pointer{}, /* pools */
 		nil,                      /* removers */
-		0,                        /* idGenerator */
+		nil,                      /* generations */
+		nil,                      /* freeList */
+		nil,                      /* systems */
+		nil,                      /* stages */
+		nil,                      /* serializables */
+		map[int]unsafe.Pointer{}, /* observers */
 	}
 }
 
-// Initializes an entity and its component. If the entity already exists, it is
-// first removed and then re-added. If the intention is not to initialize the
+// Initializes an entity and its component. If the entity already has
+// components, they are first cleared and then the given component is set;
+// the entity keeps its identity. If the intention is not to initialize the
 // entity, then use 'Set' instead.
-func Init[A any](e *ECS, entityId int, a A) {
-	e.Remove(entityId)
-	*initPool[A](e).Add(entityId) = a
+func Init[A any](e *ECS, entity Entity, a A) {
+	e.clearComponents(entity)
+	setNotify(e, entity, a)
 }
 
-func Init2[A, B any](e *ECS, entityId int, a A, b B) {
-	e.Remove(entityId)
-	Set2(e, entityId, a, b)
+func Init2[A, B any](e *ECS, entity Entity, a A, b B) {
+	e.clearComponents(entity)
+	Set2(e, entity, a, b)
 }
 
-func Init3[A, B, C any](e *ECS, entityId int, a A, b B, c C) {
-	e.Remove(entityId)
-	Set3(e, entityId, a, b, c)
+func Init3[A, B, C any](e *ECS, entity Entity, a A, b B, c C) {
+	e.clearComponents(entity)
+	Set3(e, entity, a, b, c)
 }
 
-func Init4[A, B, C, D any](e *ECS, entityId int, a A, b B, c C, d D) {
-	e.Remove(entityId)
-	Set4(e, entityId, a, b, c, d)
+func Init4[A, B, C, D any](e *ECS, entity Entity, a A, b B, c C, d D) {
+	e.clearComponents(entity)
+	Set4(e, entity, a, b, c, d)
 }
 
-func Init5[A, B, C, D, E any](ecs *ECS, entityId int, a A, b B, c C, d D, e E) {
-	ecs.Remove(entityId)
-	Set5(ecs, entityId, a, b, c, d, e)
+func Init5[A, B, C, D, E any](ecs *ECS, entity Entity, a A, b B, c C, d D, e E) {
+	ecs.clearComponents(entity)
+	Set5(ecs, entity, a, b, c, d, e)
 }
 
-func Init6[A, B, C, D, E, F any](ecs *ECS, entityId int, a A, b B, c C, d D, e E, f F) {
-	ecs.Remove(entityId)
-	Set6(ecs, entityId, a, b, c, d, e, f)
+func Init6[A, B, C, D, E, F any](ecs *ECS, entity Entity, a A, b B, c C, d D, e E, f F) {
+	ecs.clearComponents(entity)
+	Set6(ecs, entity, a, b, c, d, e, f)
 }
 
-func Init7[A, B, C, D, E, F, G any](ecs *ECS, entityId int, a A, b B, c C, d D, e E, f F, g G) {
-	ecs.Remove(entityId)
-	Set7(ecs, entityId, a, b, c, d, e, f, g)
+func Init7[A, B, C, D, E, F, G any](ecs *ECS, entity Entity, a A, b B, c C, d D, e E, f F, g G) {
+	ecs.clearComponents(entity)
+	Set7(ecs, entity, a, b, c, d, e, f, g)
 }
 
-func Init8[A, B, C, D, E, F, G, H any](ecs *ECS, entityId int, a A, b B, c C, d D, e E, f F, g G, h H) {
-	ecs.Remove(entityId)
-	Set8(ecs, entityId, a, b, c, d, e, f, g, h)
+func Init8[A, B, C, D, E, F, G, H any](ecs *ECS, entity Entity, a A, b B, c C, d D, e E, f F, g G, h H) {
+	ecs.clearComponents(entity)
+	Set8(ecs, entity, a, b, c, d, e, f, g, h)
 }
 
-// Returns a component of the given type for an entity given its ID. Returns a
-// pointer to the component and true if said entity exists, otherwise it returns
-// false.
+// Returns a component of the given type for an entity. Returns a pointer to
+// the component and true if entity is alive (see IsAlive) and has said
+// component, otherwise it returns false.
 //
 // The pointer is valid as long as the ECS is not modified (see ECS type)
-func Get[T any](e *ECS, entityId int) (*T, bool) {
+func Get[T any](e *ECS, entity Entity) (*T, bool) {
+	if !IsAlive(e, entity) {
+		return nil, false
+	}
+
 	set, ok := getPool[T](e)
 	if !ok {
 		return nil, false
 	}
 
-	return set.Get(entityId)
+	return set.Get(entity.index())
 }
 
-// Same as 'Get' for 2 component types. Returns true only if the entity has all
-// types.
+// Same as 'Get' for 2 component types. Returns true only if entity is alive
+// and has all types.
 //
 // The pointers are valid as long as the ECS is not modified (see ECS type)
-func Get2[A, B any](e *ECS, entityId int) (*A, *B, bool) {
+func Get2[A, B any](e *ECS, entity Entity) (*A, *B, bool) {
+	if !IsAlive(e, entity) {
+		return nil, nil, false
+	}
+
 	set1, ok := getPool[A](e)
 	if !ok {
 		return nil, nil, false
@@ -181,14 +274,18 @@ func Get2[A, B any](e *ECS, entityId int) (*A, *B, bool) {
 		return nil, nil, false
 	}
 
-	return sparseset.Lookup(entityId, set1, set2)
+	return sparseset.Lookup(entity.index(), set1, set2)
 }
 
-// Same as 'Get' for 3 component types. Returns true only if the entity has all
-// types.
+// Same as 'Get' for 3 component types. Returns true only if entity is alive
+// and has all types.
 //
 // The pointers are valid as long as the ECS is not modified (see ECS type)
-func Get3[A, B, C any](e *ECS, entityId int) (*A, *B, *C, bool) {
+func Get3[A, B, C any](e *ECS, entity Entity) (*A, *B, *C, bool) {
+	if !IsAlive(e, entity) {
+		return nil, nil, nil, false
+	}
+
 	set1, ok := getPool[A](e)
 	if !ok {
 		return nil, nil, nil, false
@@ -204,219 +301,248 @@ func Get3[A, B, C any](e *ECS, entityId int) (*A, *B, *C, bool) {
 		return nil, nil, nil, false
 	}
 
-	return sparseset.Lookup3(entityId, set1, set2, set3)
+	return sparseset.Lookup3(entity.index(), set1, set2, set3)
 }
 
-// Sets a component for an entity given its ID.
-func Set[A any](e *ECS, entityId int, a A) {
-	*initPool[A](e).Add(entityId) = a
+// setNotify writes value into T's pool for entity and fires OnAdd (if the
+// entity didn't already have a T) and OnSet observers registered for T. If
+// entity is not alive (see IsAlive), it's a no-op.
+func setNotify[T any](e *ECS, entity Entity, value T) {
+	if !IsAlive(e, entity) {
+		return
+	}
+
+	index := entity.index()
+	pool := initPool[T](e)
+	_, existed := pool.Get(index)
+	*pool.Add(index) = value
+	ptr, _ := pool.Get(index)
+
+	if !existed {
+		notifyAdd(e, entity, ptr)
+	}
+	notifySet(e, entity, ptr)
+}
+
+// Sets a component for an entity.
+func Set[A any](e *ECS, entity Entity, a A) {
+	setNotify(e, entity, a)
 }
 
 // Same as 'Set' for 2 component types.
-func Set2[A, B any](ecs *ECS, entityId int, a A, b B) {
-	*initPool[A](ecs).Add(entityId) = a
-	*initPool[B](ecs).Add(entityId) = b
+func Set2[A, B any](ecs *ECS, entity Entity, a A, b B) {
+	setNotify(ecs, entity, a)
+	setNotify(ecs, entity, b)
 }
 
 // Same as 'Set' for 3 component types.
-func Set3[A, B, C any](ecs *ECS, entityId int, a A, b B, c C) {
-	*initPool[A](ecs).Add(entityId) = a
-	*initPool[B](ecs).Add(entityId) = b
-	*initPool[C](ecs).Add(entityId) = c
+func Set3[A, B, C any](ecs *ECS, entity Entity, a A, b B, c C) {
+	setNotify(ecs, entity, a)
+	setNotify(ecs, entity, b)
+	setNotify(ecs, entity, c)
 }
 
 // Same as 'Set' for 4 component types.
-func Set4[A, B, C, D any](ecs *ECS, entityId int, a A, b B, c C, d D) {
-	*initPool[A](ecs).Add(entityId) = a
-	*initPool[B](ecs).Add(entityId) = b
-	*initPool[C](ecs).Add(entityId) = c
-	*initPool[D](ecs).Add(entityId) = d
+func Set4[A, B, C, D any](ecs *ECS, entity Entity, a A, b B, c C, d D) {
+	setNotify(ecs, entity, a)
+	setNotify(ecs, entity, b)
+	setNotify(ecs, entity, c)
+	setNotify(ecs, entity, d)
 }
 
 // Same as 'Set' for 5 component types.
-func Set5[A, B, C, D, E any](ecs *ECS, entityId int, a A, b B, c C, d D, e E) {
-	*initPool[A](ecs).Add(entityId) = a
-	*initPool[B](ecs).Add(entityId) = b
-	*initPool[C](ecs).Add(entityId) = c
-	*initPool[D](ecs).Add(entityId) = d
-	*initPool[E](ecs).Add(entityId) = e
+func Set5[A, B, C, D, E any](ecs *ECS, entity Entity, a A, b B, c C, d D, e E) {
+	setNotify(ecs, entity, a)
+	setNotify(ecs, entity, b)
+	setNotify(ecs, entity, c)
+	setNotify(ecs, entity, d)
+	setNotify(ecs, entity, e)
 }
 
 // Same as 'Set' for 6 component types.
-func Set6[A, B, C, D, E, F any](ecs *ECS, entityId int, a A, b B, c C, d D, e E, f F) {
-	*initPool[A](ecs).Add(entityId) = a
-	*initPool[B](ecs).Add(entityId) = b
-	*initPool[C](ecs).Add(entityId) = c
-	*initPool[D](ecs).Add(entityId) = d
-	*initPool[E](ecs).Add(entityId) = e
-	*initPool[F](ecs).Add(entityId) = f
+func Set6[A, B, C, D, E, F any](ecs *ECS, entity Entity, a A, b B, c C, d D, e E, f F) {
+	setNotify(ecs, entity, a)
+	setNotify(ecs, entity, b)
+	setNotify(ecs, entity, c)
+	setNotify(ecs, entity, d)
+	setNotify(ecs, entity, e)
+	setNotify(ecs, entity, f)
 }
 
 // Same as 'Set' for 7 component types.
-func Set7[A, B, C, D, E, F, G any](ecs *ECS, entityId int, a A, b B, c C, d D, e E, f F, g G) {
-	*initPool[A](ecs).Add(entityId) = a
-	*initPool[B](ecs).Add(entityId) = b
-	*initPool[C](ecs).Add(entityId) = c
-	*initPool[D](ecs).Add(entityId) = d
-	*initPool[E](ecs).Add(entityId) = e
-	*initPool[F](ecs).Add(entityId) = f
-	*initPool[G](ecs).Add(entityId) = g
+func Set7[A, B, C, D, E, F, G any](ecs *ECS, entity Entity, a A, b B, c C, d D, e E, f F, g G) {
+	setNotify(ecs, entity, a)
+	setNotify(ecs, entity, b)
+	setNotify(ecs, entity, c)
+	setNotify(ecs, entity, d)
+	setNotify(ecs, entity, e)
+	setNotify(ecs, entity, f)
+	setNotify(ecs, entity, g)
 }
 
 // Same as 'Set' for 8 component types.
-func Set8[A, B, C, D, E, F, G, H any](ecs *ECS, entityId int, a A, b B, c C, d D, e E, f F, g G, h H) {
-	*initPool[A](ecs).Add(entityId) = a
-	*initPool[B](ecs).Add(entityId) = b
-	*initPool[C](ecs).Add(entityId) = c
-	*initPool[D](ecs).Add(entityId) = d
-	*initPool[E](ecs).Add(entityId) = e
-	*initPool[F](ecs).Add(entityId) = f
-	*initPool[G](ecs).Add(entityId) = g
-	*initPool[H](ecs).Add(entityId) = h
-}
-
-// Removes a component from an entity given its ID. If the entity already does
-// not have said component, then it's a no-op.
-func Unset[T any](e *ECS, entityId int) {
+func Set8[A, B, C, D, E, F, G, H any](ecs *ECS, entity Entity, a A, b B, c C, d D, e E, f F, g G, h H) {
+	setNotify(ecs, entity, a)
+	setNotify(ecs, entity, b)
+	setNotify(ecs, entity, c)
+	setNotify(ecs, entity, d)
+	setNotify(ecs, entity, e)
+	setNotify(ecs, entity, f)
+	setNotify(ecs, entity, g)
+	setNotify(ecs, entity, h)
+}
+
+// Removes a component from an entity. If the entity is not alive (see
+// IsAlive) or already does not have said component, then it's a no-op.
+func Unset[T any](e *ECS, entity Entity) {
+	if !IsAlive(e, entity) {
+		return
+	}
+
 	set, ok := getPool[T](e)
 	if !ok {
 		return
 	}
 
-	set.Remove(entityId)
+	index := entity.index()
+	if _, ok := set.Get(index); !ok {
+		return
+	}
+
+	set.Remove(index)
+	notifyRemove[T](e, entity)
 }
 
 // Returns an iterator that iterates all entities that have the given component
 // type.
 //
-// for iterator := ecs.Iterate[MyComponent](e); ; {
-//   c, ok := e.Next()
-//   if !ok {
-//     break
-//   }
+//	for iterator := ecs.Iterate[MyComponent](e); ; {
+//	  entity, c, ok := iterator.Next()
+//	  if !ok {
+//	    break
+//	  }
 //
-//   // Do something with 'c'.
-// }
+//	  // Do something with 'entity' and 'c'.
+//	}
 //
 // The pointer returned by the iterator is valid as long as the ECS is not
 // modified (see ECS type)
-func Iterate[A any](e *ECS) *sparseset.Iterator[A] {
+func Iterate[A any](e *ECS) *Iterator[A] {
 	set, ok := getPool[A](e)
 	if !ok {
-		return sparseset.EmptyIterator[A]()
+		return &Iterator[A]{e, sparseset.EmptyIterator[A]()}
 	}
 
-	return sparseset.Iterate(set)
+	return &Iterator[A]{e, sparseset.Iterate(set)}
 }
 
 // Returns an iterator that iterates all entities that have all component types.
 //
-// for iterator := ecs.Join[MyComponent, OtherComponent](e); ; {
-//   c1, c2, ok := e.Next()
-//   if !ok {
-//     break
-//   }
+//	for iterator := ecs.Join[MyComponent, OtherComponent](e); ; {
+//	  entity, c1, c2, ok := iterator.Next()
+//	  if !ok {
+//	    break
+//	  }
 //
-//   // Do something with 'c1' and 'c2'.
-// }
+//	  // Do something with 'entity', 'c1' and 'c2'.
+//	}
 //
 // The pointers returned by the iterator are valid as long as the ECS is not
 // modified (see ECS type)
-func Join[A, B any](e *ECS) *sparseset.JoinIterator[A, B] {
+func Join[A, B any](e *ECS) *JoinIterator[A, B] {
 	set1, ok := getPool[A](e)
 	if !ok {
-		return sparseset.EmptyJoinIterator[A, B]()
+		return &JoinIterator[A, B]{e, sparseset.EmptyJoinIterator[A, B]()}
 	}
 
 	set2, ok := getPool[B](e)
 	if !ok {
-		return sparseset.EmptyJoinIterator[A, B]()
+		return &JoinIterator[A, B]{e, sparseset.EmptyJoinIterator[A, B]()}
 	}
 
-	return sparseset.Join(set1, set2)
+	return &JoinIterator[A, B]{e, sparseset.Join(set1, set2)}
 }
 
 // Same as 'Join' for 3 component types.
-func Join3[A, B, C any](e *ECS) *sparseset.Join3Iterator[A, B, C] {
+func Join3[A, B, C any](e *ECS) *Join3Iterator[A, B, C] {
 	set1, ok := getPool[A](e)
 	if !ok {
-		return sparseset.EmptyJoin3Iterator[A, B, C]()
+		return &Join3Iterator[A, B, C]{e, sparseset.EmptyJoin3Iterator[A, B, C]()}
 	}
 
 	set2, ok := getPool[B](e)
 	if !ok {
-		return sparseset.EmptyJoin3Iterator[A, B, C]()
+		return &Join3Iterator[A, B, C]{e, sparseset.EmptyJoin3Iterator[A, B, C]()}
 	}
 
 	set3, ok := getPool[C](e)
 	if !ok {
-		return sparseset.EmptyJoin3Iterator[A, B, C]()
+		return &Join3Iterator[A, B, C]{e, sparseset.EmptyJoin3Iterator[A, B, C]()}
 	}
 
-	return sparseset.Join3(set1, set2, set3)
+	return &Join3Iterator[A, B, C]{e, sparseset.Join3(set1, set2, set3)}
 }
 
 // Same as 'Join' for 4 component types.
-func Join4[A, B, C, D any](e *ECS) *sparseset.Join4Iterator[A, B, C, D] {
+func Join4[A, B, C, D any](e *ECS) *Join4Iterator[A, B, C, D] {
 	set1, ok := getPool[A](e)
 	if !ok {
-		return sparseset.EmptyJoin4Iterator[A, B, C, D]()
+		return &Join4Iterator[A, B, C, D]{e, sparseset.EmptyJoin4Iterator[A, B, C, D]()}
 	}
 
 	set2, ok := getPool[B](e)
 	if !ok {
-		return sparseset.EmptyJoin4Iterator[A, B, C, D]()
+		return &Join4Iterator[A, B, C, D]{e, sparseset.EmptyJoin4Iterator[A, B, C, D]()}
 	}
 
 	set3, ok := getPool[C](e)
 	if !ok {
-		return sparseset.EmptyJoin4Iterator[A, B, C, D]()
+		return &Join4Iterator[A, B, C, D]{e, sparseset.EmptyJoin4Iterator[A, B, C, D]()}
 	}
 
 	set4, ok := getPool[D](e)
 	if !ok {
-		return sparseset.EmptyJoin4Iterator[A, B, C, D]()
+		return &Join4Iterator[A, B, C, D]{e, sparseset.EmptyJoin4Iterator[A, B, C, D]()}
 	}
 
-	return sparseset.Join4(set1, set2, set3, set4)
+	return &Join4Iterator[A, B, C, D]{e, sparseset.Join4(set1, set2, set3, set4)}
 }
 
-// Returns any entity that has the given component. Returns the entity ID, the
+// Returns any entity that has the given component. Returns the entity, the
 // pointer to the component and true if said entity exists, otherwise it returns
 // false.
 //
 // The pointer is valid as long as the ECS is not modified (see ECS type)
-func IterateAny[T any](e *ECS) (int, *T, bool) {
+func IterateAny[T any](e *ECS) (Entity, *T, bool) {
 	iterator := Iterate[T](e)
-	entityId, t, ok := iterator.Next()
-	return entityId, t, ok
+	return iterator.Next()
 }
 
-// Returns any entity that has all the given components. Returns the entity ID,
+// Returns any entity that has all the given components. Returns the entity,
 // the pointers to the components and true if said entitiy exists, otherwise it
 // returns false. The pointers are valid as long as the ECS is not modified.
 //
 // The pointers are valid as long as the ECS is not modified (see ECS type)
-func JoinAny[A, B any](e *ECS) (int, *A, *B, bool) {
+func JoinAny[A, B any](e *ECS) (Entity, *A, *B, bool) {
 	iterator := Join[A, B](e)
-	entityId, a, b, ok := iterator.Next()
-	return entityId, a, b, ok
+	return iterator.Next()
 }
 
-func Join3Any[A, B, C any](e *ECS) (int, *A, *B, *C, bool) {
+func Join3Any[A, B, C any](e *ECS) (Entity, *A, *B, *C, bool) {
 	iterator := Join3[A, B, C](e)
-	entityId, a, b, c, ok := iterator.Next()
-	return entityId, a, b, c, ok
+	return iterator.Next()
 }
 
 // Sorts the components using a stable sort function according to the given
 // comparator function. The comparator function uses the same semantics are
 // 'cmp.Compare' from the http://pkg.go.dev/cmp package.
-func SortStableFunc[T any](e *ECS, compare func(int, *T, int, *T) int) {
+func SortStableFunc[T any](e *ECS, compare func(Entity, *T, Entity, *T) int) {
 	set, ok := getPool[T](e)
 	if !ok {
 		return
 	}
 
-	sparseset.SortStableFunc(set, compare)
+	sparseset.SortStableFunc(set, func(ai int, a *T, bi int, b *T) int {
+		return compare(newEntity(ai, e.generations[ai]), a, newEntity(bi, e.generations[bi]), b)
+	})
 }
@@ -0,0 +1,27 @@
+package ecs
+
+// Entity identifies an entity. It packs a pool index (the low 32 bits) with
+// a generation counter (the high 32 bits), so that a stale Entity value
+// referring to a since-removed and recycled index can be detected instead of
+// silently aliasing whatever entity now occupies that index.
+type Entity uint64
+
+func newEntity(index int, generation uint32) Entity {
+	return Entity(uint64(generation)<<32 | uint64(uint32(index)))
+}
+
+func (id Entity) index() int {
+	return int(uint32(id))
+}
+
+func (id Entity) generation() uint32 {
+	return uint32(id >> 32)
+}
+
+// IsAlive reports whether id still refers to a live entity in e: its index
+// must be within e's generation table, and its generation must match the
+// current generation for that index.
+func IsAlive(e *ECS, id Entity) bool {
+	index := id.index()
+	return index >= 0 && index < len(e.generations) && e.generations[index] == id.generation()
+}
@@ -0,0 +1,59 @@
+package ecs
+
+import "testing"
+
+func TestAddRecyclesIndexAndBumpsGeneration(t *testing.T) {
+	e := New()
+
+	a := e.Add()
+	e.Remove(a)
+
+	b := e.Add()
+
+	if a.index() != b.index() {
+		t.Fatalf("expected Add to recycle index %d, got %d", a.index(), b.index())
+	}
+	if b.generation() != a.generation()+1 {
+		t.Fatalf("generation = %d, want %d", b.generation(), a.generation()+1)
+	}
+}
+
+func TestIsAliveAfterRemove(t *testing.T) {
+	e := New()
+
+	a := e.Add()
+	if !IsAlive(e, a) {
+		t.Fatal("freshly added entity should be alive")
+	}
+
+	e.Remove(a)
+	if IsAlive(e, a) {
+		t.Fatal("removed entity should not be alive")
+	}
+
+	b := e.Add()
+	if IsAlive(e, a) {
+		t.Fatal("stale handle to a recycled index should not be alive")
+	}
+	if !IsAlive(e, b) {
+		t.Fatal("recycled entity should be alive")
+	}
+}
+
+func TestRemoveIsNoopForDeadEntity(t *testing.T) {
+	e := New()
+
+	a := e.Add()
+	e.Remove(a)
+	e.Remove(a) // should not panic or double-free the index
+
+	b := e.Add()
+	if b.index() != a.index() {
+		t.Fatalf("expected Add to recycle index %d, got %d", a.index(), b.index())
+	}
+
+	c := e.Add()
+	if c.index() == a.index() {
+		t.Fatalf("index %d was recycled twice", a.index())
+	}
+}
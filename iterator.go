@@ -0,0 +1,75 @@
+package ecs
+
+import "github.com/jabolopes/go-sparseset"
+
+// Iterator wraps a sparseset.Iterator to yield Entity values instead of raw
+// pool indices.
+type Iterator[A any] struct {
+	e    *ECS
+	iter *sparseset.Iterator[A]
+}
+
+// Next returns the next entity and its component. The pointer returned is
+// valid as long as the ECS is not modified (see ECS type).
+func (it *Iterator[A]) Next() (Entity, *A, bool) {
+	index, a, ok := it.iter.Next()
+	if !ok {
+		return 0, nil, false
+	}
+
+	return newEntity(index, it.e.generations[index]), a, true
+}
+
+// JoinIterator wraps a sparseset.JoinIterator to yield Entity values instead
+// of raw pool indices.
+type JoinIterator[A, B any] struct {
+	e    *ECS
+	iter *sparseset.JoinIterator[A, B]
+}
+
+// Next returns the next entity and its components. The pointers returned
+// are valid as long as the ECS is not modified (see ECS type).
+func (it *JoinIterator[A, B]) Next() (Entity, *A, *B, bool) {
+	index, a, b, ok := it.iter.Next()
+	if !ok {
+		return 0, nil, nil, false
+	}
+
+	return newEntity(index, it.e.generations[index]), a, b, true
+}
+
+// Join3Iterator wraps a sparseset.Join3Iterator to yield Entity values
+// instead of raw pool indices.
+type Join3Iterator[A, B, C any] struct {
+	e    *ECS
+	iter *sparseset.Join3Iterator[A, B, C]
+}
+
+// Next returns the next entity and its components. The pointers returned
+// are valid as long as the ECS is not modified (see ECS type).
+func (it *Join3Iterator[A, B, C]) Next() (Entity, *A, *B, *C, bool) {
+	index, a, b, c, ok := it.iter.Next()
+	if !ok {
+		return 0, nil, nil, nil, false
+	}
+
+	return newEntity(index, it.e.generations[index]), a, b, c, true
+}
+
+// Join4Iterator wraps a sparseset.Join4Iterator to yield Entity values
+// instead of raw pool indices.
+type Join4Iterator[A, B, C, D any] struct {
+	e    *ECS
+	iter *sparseset.Join4Iterator[A, B, C, D]
+}
+
+// Next returns the next entity and its components. The pointers returned
+// are valid as long as the ECS is not modified (see ECS type).
+func (it *Join4Iterator[A, B, C, D]) Next() (Entity, *A, *B, *C, *D, bool) {
+	index, a, b, c, d, ok := it.iter.Next()
+	if !ok {
+		return 0, nil, nil, nil, nil, false
+	}
+
+	return newEntity(index, it.e.generations[index]), a, b, c, d, true
+}
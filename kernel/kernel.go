@@ -0,0 +1,228 @@
+// Package kernel lets callers describe per-entity component update logic as
+// a small typed expression tree instead of a hand-written Go loop, and
+// compiles that tree into a closure that runs tightly over an ecs.Join
+// iterator. Because the logic is data (an AST) rather than a closure
+// capturing arbitrary Go code, the engine can later change how a compiled
+// kernel is executed (batching, parallel chunks, ...) without users having
+// to rewrite their systems.
+package kernel
+
+import (
+	"reflect"
+	"unsafe"
+
+	"github.com/jabolopes/go-ecs"
+)
+
+// Expr is a node in a kernel's expression tree. Expressions read float64
+// fields from the pair of components a compiled kernel operates on.
+type Expr interface {
+	eval(a, b unsafe.Pointer) float64
+}
+
+type constExpr float64
+
+func (c constExpr) eval(a, b unsafe.Pointer) float64 { return float64(c) }
+
+type fieldExpr struct {
+	offset uintptr
+	onB    bool
+}
+
+func (f fieldExpr) eval(a, b unsafe.Pointer) float64 {
+	base := a
+	if f.onB {
+		base = b
+	}
+
+	return *(*float64)(unsafe.Pointer(uintptr(base) + f.offset))
+}
+
+type binExpr struct {
+	op   byte
+	l, r Expr
+}
+
+func (e binExpr) eval(a, b unsafe.Pointer) float64 {
+	l := e.l.eval(a, b)
+	r := e.r.eval(a, b)
+
+	switch e.op {
+	case '+':
+		return l + r
+	case '-':
+		return l - r
+	case '*':
+		return l * r
+	default:
+		panic("kernel: unknown operator")
+	}
+}
+
+// Cond is a boolean condition over two Exprs, built by Lt/Gt/Eq and consumed
+// by If. It's its own interface rather than an Expr so that a condition
+// can't accidentally be plugged in where a float64-producing Expr is
+// expected.
+type Cond interface {
+	eval(a, b unsafe.Pointer) bool
+}
+
+type cmpExpr struct {
+	op   byte
+	l, r Expr
+}
+
+func (c cmpExpr) eval(a, b unsafe.Pointer) bool {
+	l := c.l.eval(a, b)
+	r := c.r.eval(a, b)
+
+	switch c.op {
+	case '<':
+		return l < r
+	case '>':
+		return l > r
+	case '=':
+		return l == r
+	default:
+		panic("kernel: unknown comparison")
+	}
+}
+
+// ifExpr is the per-entity control-flow node: it evaluates cond and yields
+// then's or els's value without evaluating the branch not taken.
+type ifExpr struct {
+	cond      Cond
+	then, els Expr
+}
+
+func (e ifExpr) eval(a, b unsafe.Pointer) float64 {
+	if e.cond.eval(a, b) {
+		return e.then.eval(a, b)
+	}
+
+	return e.els.eval(a, b)
+}
+
+// store is a single compiled operation: evaluate expr and write the result
+// back into the float64 field at offset, within the first component.
+type store struct {
+	offset uintptr
+	expr   Expr
+}
+
+// Builder accumulates the expressions and stores that make up a compiled
+// kernel. A Builder is only valid for the duration of the function passed to
+// Compile.
+type Builder struct {
+	typeA, typeB reflect.Type
+	stores       []store
+}
+
+func fieldOffset(t reflect.Type, name string) uintptr {
+	f, ok := t.FieldByName(name)
+	if !ok {
+		panic("kernel: no such field " + name + " on " + t.String())
+	}
+
+	if f.Type.Kind() != reflect.Float64 {
+		panic("kernel: field " + name + " on " + t.String() + " is " + f.Type.Kind().String() + ", not float64")
+	}
+
+	return f.Offset
+}
+
+// Field references a float64 field of the first component type by name.
+func (b *Builder) Field(name string) Expr {
+	return fieldExpr{offset: fieldOffset(b.typeA, name)}
+}
+
+// Field2 references a float64 field of the second component type by name.
+func (b *Builder) Field2(name string) Expr {
+	return fieldExpr{offset: fieldOffset(b.typeB, name), onB: true}
+}
+
+// Const returns a literal constant expression.
+func (b *Builder) Const(v float64) Expr {
+	return constExpr(v)
+}
+
+// Mul returns an expression computing l * r.
+func (b *Builder) Mul(l, r Expr) Expr {
+	return binExpr{'*', l, r}
+}
+
+// Sub returns an expression computing l - r.
+func (b *Builder) Sub(l, r Expr) Expr {
+	return binExpr{'-', l, r}
+}
+
+// Store schedules expr to be written back into dst, a field of the first
+// component type, every time the compiled kernel runs.
+func (b *Builder) Store(dst Expr, expr Expr) {
+	fe, ok := dst.(fieldExpr)
+	if !ok || fe.onB {
+		panic("kernel: store destination must be a field of the first component type")
+	}
+
+	b.stores = append(b.stores, store{offset: fe.offset, expr: expr})
+}
+
+// Add schedules dst += src, i.e. it's equivalent to Store(dst, Add(dst, src))
+// where Add here means the arithmetic sum, not this method.
+func (b *Builder) Add(dst Expr, src Expr) {
+	b.Store(dst, binExpr{'+', dst, src})
+}
+
+// Lt returns a condition that's true when l < r, for use with If.
+func (b *Builder) Lt(l, r Expr) Cond {
+	return cmpExpr{'<', l, r}
+}
+
+// Gt returns a condition that's true when l > r, for use with If.
+func (b *Builder) Gt(l, r Expr) Cond {
+	return cmpExpr{'>', l, r}
+}
+
+// Eq returns a condition that's true when l == r, for use with If.
+func (b *Builder) Eq(l, r Expr) Cond {
+	return cmpExpr{'=', l, r}
+}
+
+// If returns an expression that evaluates to then's value when cond holds
+// for the current entity, and to els's value otherwise. This is the kernel
+// DSL's per-entity control flow: unlike a Go if inside a hand-written
+// system, it stays data so the compiled kernel can still run the same store
+// loop over every entity.
+func (b *Builder) If(cond Cond, then, els Expr) Expr {
+	return ifExpr{cond: cond, then: then, els: els}
+}
+
+// Compile builds a kernel's expression tree via build and returns a closure
+// that applies it to every entity matching Join[A, B], in place.
+func Compile[A, B any](build func(b *Builder)) func(*ecs.ECS) {
+	var a A
+	var b B
+
+	builder := &Builder{
+		typeA: reflect.TypeOf(a),
+		typeB: reflect.TypeOf(b),
+	}
+	build(builder)
+	stores := builder.stores
+
+	return func(e *ecs.ECS) {
+		iterator := ecs.Join[A, B](e)
+		for {
+			_, ca, cb, ok := iterator.Next()
+			if !ok {
+				break
+			}
+
+			pa := unsafe.Pointer(ca)
+			pb := unsafe.Pointer(cb)
+			for _, s := range stores {
+				*(*float64)(unsafe.Pointer(uintptr(pa) + s.offset)) = s.expr.eval(pa, pb)
+			}
+		}
+	}
+}
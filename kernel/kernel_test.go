@@ -0,0 +1,76 @@
+package kernel_test
+
+import (
+	"testing"
+
+	ecs "github.com/jabolopes/go-ecs"
+	"github.com/jabolopes/go-ecs/kernel"
+)
+
+type kernelPos struct{ X, Y float64 }
+type kernelVel struct{ VX, VY float64 }
+
+func TestCompileAppliesOverJoin(t *testing.T) {
+	e := ecs.New()
+
+	moveX := kernel.Compile[kernelPos, kernelVel](func(b *kernel.Builder) {
+		b.Add(b.Field("X"), b.Mul(b.Field2("VX"), b.Const(2)))
+	})
+
+	id := e.Add()
+	ecs.Set2(e, id, kernelPos{X: 1}, kernelVel{VX: 3})
+
+	other := e.Add()
+	ecs.Set(e, other, kernelPos{X: 100})
+
+	moveX(e)
+
+	pos, _ := ecs.Get[kernelPos](e, id)
+	if pos.X != 7 {
+		t.Fatalf("pos.X = %v, want 7", pos.X)
+	}
+
+	untouched, _ := ecs.Get[kernelPos](e, other)
+	if untouched.X != 100 {
+		t.Fatalf("pos.X for entity without Vel = %v, want unchanged 100", untouched.X)
+	}
+}
+
+func TestCompileIf(t *testing.T) {
+	e := ecs.New()
+
+	clampX := kernel.Compile[kernelPos, kernelVel](func(b *kernel.Builder) {
+		b.Store(b.Field("X"), b.If(b.Gt(b.Field("X"), b.Const(0)), b.Const(1), b.Const(-1)))
+	})
+
+	pos := e.Add()
+	ecs.Set2(e, pos, kernelPos{X: 5}, kernelVel{})
+	neg := e.Add()
+	ecs.Set2(e, neg, kernelPos{X: -5}, kernelVel{})
+
+	clampX(e)
+
+	p, _ := ecs.Get[kernelPos](e, pos)
+	if p.X != 1 {
+		t.Fatalf("pos.X = %v, want 1", p.X)
+	}
+
+	n, _ := ecs.Get[kernelPos](e, neg)
+	if n.X != -1 {
+		t.Fatalf("neg.X = %v, want -1", n.X)
+	}
+}
+
+func TestFieldPanicsOnNonFloat64(t *testing.T) {
+	type withInt struct{ N int }
+
+	defer func() {
+		if recover() == nil {
+			t.Fatal("expected panic referencing a non-float64 field")
+		}
+	}()
+
+	kernel.Compile[withInt, kernelVel](func(b *kernel.Builder) {
+		b.Field("N")
+	})
+}
@@ -0,0 +1,88 @@
+package ecs
+
+import "unsafe"
+
+// componentObservers holds the callbacks registered for a single component
+// type via OnAdd, OnSet, and OnRemove.
+type componentObservers[T any] struct {
+	onAdd    []func(e *ECS, entity Entity, value *T)
+	onSet    []func(e *ECS, entity Entity, value *T)
+	onRemove []func(e *ECS, entity Entity)
+}
+
+// getObservers returns the componentObservers for T, creating it if create
+// is true and none exists yet.
+func getObservers[T any](e *ECS, create bool) *componentObservers[T] {
+	typeId := getTypeId[T]()
+
+	obs, ok := e.observers[typeId]
+	if !ok {
+		if !create {
+			return nil
+		}
+
+		observers := &componentObservers[T]{}
+		e.observers[typeId] = unsafe.Pointer(observers)
+		return observers
+	}
+
+	return (*componentObservers[T])(obs)
+}
+
+// OnAdd registers fn to run whenever a component of type T is added to an
+// entity that didn't already have one, via Init, Set, or any of their
+// multi-component variants.
+func OnAdd[T any](e *ECS, fn func(e *ECS, entity Entity, value *T)) {
+	o := getObservers[T](e, true)
+	o.onAdd = append(o.onAdd, fn)
+}
+
+// OnSet registers fn to run whenever a component of type T is written via
+// Init, Set, or any of their multi-component variants, whether or not the
+// entity already had one. For a newly added component, OnSet observers run
+// after OnAdd observers.
+func OnSet[T any](e *ECS, fn func(e *ECS, entity Entity, value *T)) {
+	o := getObservers[T](e, true)
+	o.onSet = append(o.onSet, fn)
+}
+
+// OnRemove registers fn to run whenever a component of type T is removed
+// from an entity, via Unset, by removing the entity itself with Remove, or
+// by Init and its multi-component variants clearing the entity first.
+func OnRemove[T any](e *ECS, fn func(e *ECS, entity Entity)) {
+	o := getObservers[T](e, true)
+	o.onRemove = append(o.onRemove, fn)
+}
+
+func notifyAdd[T any](e *ECS, entity Entity, value *T) {
+	o := getObservers[T](e, false)
+	if o == nil {
+		return
+	}
+
+	for _, fn := range o.onAdd {
+		fn(e, entity, value)
+	}
+}
+
+func notifySet[T any](e *ECS, entity Entity, value *T) {
+	o := getObservers[T](e, false)
+	if o == nil {
+		return
+	}
+
+	for _, fn := range o.onSet {
+		fn(e, entity, value)
+	}
+}
+
+func notifyRemove[T any](e *ECS, entity Entity) {
+	o := getObservers[T](e, false)
+	if o == nil {
+		return
+	}
+
+	for _, fn := range o.onRemove {
+		fn(e, entity)
+	}
+}
@@ -0,0 +1,305 @@
+package ecs
+
+import (
+	"bytes"
+	"encoding/gob"
+	"fmt"
+	"io"
+
+	"github.com/jabolopes/go-sparseset"
+)
+
+// serializable holds the type-erased operations needed to snapshot, restore,
+// diff, and apply a single component type's pool. It's keyed on a
+// user-supplied wire name rather than the process-local type ID that
+// getTypeId hands out, so that a snapshot taken in one process can be
+// restored in another. Components are keyed by pool index rather than
+// Entity on the wire, since the generation half of an Entity is meaningful
+// only within the ECS that minted it.
+type serializable struct {
+	name string
+
+	values func(e *ECS) map[int][]byte
+
+	// restore merges values into T's pool via Set, without touching any
+	// index absent from values. Apply uses this for Changed, since a
+	// delta's Removed already lists indices to clear explicitly.
+	restore func(e *ECS, values map[int][]byte) error
+
+	// replace makes T's pool match values exactly: indices in values are
+	// Set, and any index T's pool already has that's absent from values is
+	// Unset, so OnRemove fires for it. Restore uses this, since a snapshot
+	// is the full, authoritative state for every registered type rather
+	// than a partial delta.
+	replace func(e *ECS, values map[int][]byte) error
+
+	removeOne func(e *ECS, index int)
+}
+
+func encodeValue(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(v); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// RegisterSerializable opts component type T into Snapshot, Restore, Diff,
+// and Apply under the given wire name.
+//
+// RegisterSerializable reports an error, without registering the type, if
+// name is already registered, since snapshots identify component pools by
+// name.
+func RegisterSerializable[T any](e *ECS, name string) error {
+	for _, s := range e.serializables {
+		if s.name == name {
+			return fmt.Errorf("ecs: serializable %q is already registered", name)
+		}
+	}
+
+	e.serializables = append(e.serializables, &serializable{
+		name: name,
+		values: func(e *ECS) map[int][]byte {
+			out := map[int][]byte{}
+
+			set, ok := getPool[T](e)
+			if !ok {
+				return out
+			}
+
+			iterator := sparseset.Iterate(set)
+			for {
+				index, t, ok := iterator.Next()
+				if !ok {
+					break
+				}
+
+				data, err := encodeValue(*t)
+				if err != nil {
+					panic(fmt.Sprintf("ecs: encoding %q: %v", name, err))
+				}
+				out[index] = data
+			}
+
+			return out
+		},
+		restore: func(e *ECS, values map[int][]byte) error {
+			for index, data := range values {
+				var t T
+				if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&t); err != nil {
+					return err
+				}
+				e.ensureGeneration(index)
+				Set(e, newEntity(index, e.generations[index]), t)
+			}
+
+			return nil
+		},
+		replace: func(e *ECS, values map[int][]byte) error {
+			stale := map[int]bool{}
+			if set, ok := getPool[T](e); ok {
+				iterator := sparseset.Iterate(set)
+				for {
+					index, _, ok := iterator.Next()
+					if !ok {
+						break
+					}
+					stale[index] = true
+				}
+			}
+
+			for index, data := range values {
+				var t T
+				if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&t); err != nil {
+					return err
+				}
+				e.ensureGeneration(index)
+				Set(e, newEntity(index, e.generations[index]), t)
+				delete(stale, index)
+			}
+
+			for index := range stale {
+				e.ensureGeneration(index)
+				Unset[T](e, newEntity(index, e.generations[index]))
+			}
+
+			return nil
+		},
+		removeOne: func(e *ECS, index int) {
+			e.ensureGeneration(index)
+			Unset[T](e, newEntity(index, e.generations[index]))
+		},
+	})
+
+	return nil
+}
+
+// snapshotEnvelope is the wire format written by Snapshot and read by
+// Restore.
+type snapshotEnvelope struct {
+	Generations []uint32
+	FreeList    []int
+	Components  map[string]map[int][]byte
+}
+
+// Snapshot writes the full state of every type registered via
+// RegisterSerializable, plus the entity generation table and free list, to
+// w.
+func Snapshot(e *ECS, w io.Writer) error {
+	env := snapshotEnvelope{
+		Generations: e.generations,
+		FreeList:    e.freeList,
+		Components:  map[string]map[int][]byte{},
+	}
+
+	for _, s := range e.serializables {
+		env.Components[s.name] = s.values(e)
+	}
+
+	if err := gob.NewEncoder(w).Encode(env); err != nil {
+		return fmt.Errorf("ecs: snapshot: %w", err)
+	}
+
+	return nil
+}
+
+// Restore replaces e's state with a snapshot previously written by
+// Snapshot. Only component types registered via RegisterSerializable on e
+// are restored: each such type's pool is made to match the snapshot
+// exactly, Setting whatever it contains and Unsetting (firing OnRemove)
+// whatever e had that the snapshot doesn't, so e.g. a View watching a
+// registered type observes the restore instead of going stale. Component
+// types that aren't registered are left completely untouched by Restore,
+// including entities that otherwise have their identity (generation)
+// reset by the restored generation table.
+func Restore(e *ECS, r io.Reader) error {
+	var env snapshotEnvelope
+	if err := gob.NewDecoder(r).Decode(&env); err != nil {
+		return fmt.Errorf("ecs: restore: %w", err)
+	}
+
+	e.generations = env.Generations
+	e.freeList = env.FreeList
+
+	for _, s := range e.serializables {
+		values, ok := env.Components[s.name]
+		if !ok {
+			values = map[int][]byte{}
+		}
+
+		if err := s.replace(e, values); err != nil {
+			return fmt.Errorf("ecs: restore %q: %w", s.name, err)
+		}
+	}
+
+	return nil
+}
+
+// deltaEnvelope is the wire format written by Diff and read by Apply.
+//
+// Generations carries, for every pool index whose generation differs
+// between prev and cur, cur's generation at that index. This is what lets
+// Apply tell a removal-and-recycle (the index's occupant was removed and a
+// new entity minted in its place) apart from an in-place update: without
+// it, Apply would see only a changed component value at the index and
+// attach it to whatever generation the target ECS already had there,
+// silently keeping any Entity a caller held across the removal "alive".
+//
+// FreeList carries cur's free list whole, so that the target's next Add
+// recycles the same index cur's would, and never an index that a live
+// entity holds after Apply.
+type deltaEnvelope struct {
+	Changed     map[string]map[int][]byte
+	Removed     map[string][]int
+	Generations map[int]uint32
+	FreeList    []int
+}
+
+// Diff returns the changes needed to turn prev into cur, across every type
+// registered (on cur) via RegisterSerializable. The result is suitable for
+// transmitting over the network (rollback netcode) or storing as a delta in
+// a time-travel debugger; apply it to prev with Apply to reproduce cur.
+func Diff(prev, cur *ECS) ([]byte, error) {
+	env := deltaEnvelope{
+		Changed:     map[string]map[int][]byte{},
+		Removed:     map[string][]int{},
+		Generations: map[int]uint32{},
+		FreeList:    cur.freeList,
+	}
+
+	for index, gen := range cur.generations {
+		var prevGen uint32
+		if index < len(prev.generations) {
+			prevGen = prev.generations[index]
+		}
+
+		if gen != prevGen {
+			env.Generations[index] = gen
+		}
+	}
+
+	for _, s := range cur.serializables {
+		prevValues := s.values(prev)
+		curValues := s.values(cur)
+
+		changed := map[int][]byte{}
+		for index, data := range curValues {
+			if !bytes.Equal(prevValues[index], data) {
+				changed[index] = data
+			}
+		}
+		if len(changed) > 0 {
+			env.Changed[s.name] = changed
+		}
+
+		var removed []int
+		for index := range prevValues {
+			if _, ok := curValues[index]; !ok {
+				removed = append(removed, index)
+			}
+		}
+		if len(removed) > 0 {
+			env.Removed[s.name] = removed
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := gob.NewEncoder(&buf).Encode(env); err != nil {
+		return nil, fmt.Errorf("ecs: diff: %w", err)
+	}
+
+	return buf.Bytes(), nil
+}
+
+// Apply applies a delta previously produced by Diff to e.
+func Apply(e *ECS, delta []byte) error {
+	var env deltaEnvelope
+	if err := gob.NewDecoder(bytes.NewReader(delta)).Decode(&env); err != nil {
+		return fmt.Errorf("ecs: apply: %w", err)
+	}
+
+	// Generations must land before Changed/Removed below: a recycled index
+	// needs its bumped generation in place first, both so removeOne tears
+	// down the old occupant under the right Entity and so restore mints the
+	// new occupant's Entity at the new generation instead of the stale one.
+	for index, gen := range env.Generations {
+		e.ensureGeneration(index)
+		e.generations[index] = gen
+	}
+	e.freeList = env.FreeList
+
+	for _, s := range e.serializables {
+		if values, ok := env.Changed[s.name]; ok {
+			if err := s.restore(e, values); err != nil {
+				return fmt.Errorf("ecs: apply %q: %w", s.name, err)
+			}
+		}
+
+		for _, index := range env.Removed[s.name] {
+			s.removeOne(e, index)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,202 @@
+package ecs
+
+import (
+	"bytes"
+	"testing"
+)
+
+type serHealth struct{ HP int }
+
+func TestSnapshotRestoreRoundTrip(t *testing.T) {
+	e := New()
+	if err := RegisterSerializable[serHealth](e, "health"); err != nil {
+		t.Fatal(err)
+	}
+
+	a := e.Add()
+	Set(e, a, serHealth{HP: 10})
+	b := e.Add()
+	Set(e, b, serHealth{HP: 20})
+
+	var buf bytes.Buffer
+	if err := Snapshot(e, &buf); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+
+	// Mutate after the snapshot so Restore has something to undo.
+	Set(e, a, serHealth{HP: 999})
+	e.Remove(b)
+
+	if err := Restore(e, &buf); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+
+	hp, ok := Get[serHealth](e, a)
+	if !ok || hp.HP != 10 {
+		t.Fatalf("Get(a) = %v, %v, want 10, true", hp, ok)
+	}
+	if !IsAlive(e, b) {
+		t.Fatal("restore should bring back a removed entity")
+	}
+	hp, ok = Get[serHealth](e, b)
+	if !ok || hp.HP != 20 {
+		t.Fatalf("Get(b) = %v, %v, want 20, true", hp, ok)
+	}
+}
+
+func TestRegisterSerializableRejectsDuplicateName(t *testing.T) {
+	e := New()
+
+	if err := RegisterSerializable[serHealth](e, "health"); err != nil {
+		t.Fatalf("first registration: %v", err)
+	}
+
+	if err := RegisterSerializable[serHealth](e, "health"); err == nil {
+		t.Fatal("expected error registering a duplicate serializable name")
+	}
+}
+
+type serTag struct{ Name string }
+
+// TestRestorePreservesUnregisteredPools reproduces the scenario where an
+// entity has both a registered (Health) and an unregistered (Tag)
+// component: Restore must not silently destroy Tag just because the
+// caller never opted it into serialization.
+func TestRestorePreservesUnregisteredPools(t *testing.T) {
+	e := New()
+	if err := RegisterSerializable[serHealth](e, "health"); err != nil {
+		t.Fatal(err)
+	}
+
+	a := e.Add()
+	Set2(e, a, serHealth{HP: 10}, serTag{Name: "boss"})
+
+	var buf bytes.Buffer
+	if err := Snapshot(e, &buf); err != nil {
+		t.Fatalf("snapshot: %v", err)
+	}
+
+	Set(e, a, serHealth{HP: 1})
+
+	if err := Restore(e, &buf); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+
+	tag, ok := Get[serTag](e, a)
+	if !ok || tag.Name != "boss" {
+		t.Fatalf("Get[serTag](a) = %v, %v, want \"boss\", true; Restore must not touch unregistered pools", tag, ok)
+	}
+}
+
+// TestRestoreNotifiesViewOfVanishedComponent reproduces the scenario where
+// a View is built before a Restore that drops a registered component the
+// view watches: the view must observe the removal via OnRemove instead of
+// going stale.
+func TestRestoreNotifiesViewOfVanishedComponent(t *testing.T) {
+	e := New()
+	if err := RegisterSerializable[serHealth](e, "health"); err != nil {
+		t.Fatal(err)
+	}
+
+	a := e.Add()
+	Set(e, a, serHealth{HP: 10})
+
+	view := NewView[serHealth](e)
+	if view.Len() != 1 {
+		t.Fatalf("Len() = %d, want 1 before restore", view.Len())
+	}
+
+	empty := New()
+	if err := RegisterSerializable[serHealth](empty, "health"); err != nil {
+		t.Fatal(err)
+	}
+	var emptyBuf bytes.Buffer
+	if err := Snapshot(empty, &emptyBuf); err != nil {
+		t.Fatalf("snapshot empty: %v", err)
+	}
+
+	if err := Restore(e, &emptyBuf); err != nil {
+		t.Fatalf("restore: %v", err)
+	}
+
+	if view.Len() != 0 {
+		t.Fatalf("Len() = %d, want 0 after restoring a snapshot with no serHealth entries", view.Len())
+	}
+}
+
+func TestDiffApplyRoundTrip(t *testing.T) {
+	prev := New()
+	if err := RegisterSerializable[serHealth](prev, "health"); err != nil {
+		t.Fatal(err)
+	}
+	a := prev.Add()
+	Set(prev, a, serHealth{HP: 10})
+
+	cur := New()
+	if err := RegisterSerializable[serHealth](cur, "health"); err != nil {
+		t.Fatal(err)
+	}
+	a2 := cur.Add()
+	Set(cur, a2, serHealth{HP: 7})
+
+	delta, err := Diff(prev, cur)
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if err := Apply(prev, delta); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+
+	hp, ok := Get[serHealth](prev, a)
+	if !ok || hp.HP != 7 {
+		t.Fatalf("Get(a) after apply = %v, %v, want 7, true", hp, ok)
+	}
+}
+
+// TestDiffApplyInvalidatesRecycledIndex reproduces the scenario where an
+// index is removed and immediately recycled into a new entity between prev
+// and cur: Apply must invalidate any stale handle to the old occupant
+// instead of silently attaching the new occupant's data to it.
+func TestDiffApplyInvalidatesRecycledIndex(t *testing.T) {
+	prev := New()
+	if err := RegisterSerializable[serHealth](prev, "health"); err != nil {
+		t.Fatal(err)
+	}
+	a := prev.Add()
+	Set(prev, a, serHealth{HP: 10})
+
+	cur := New()
+	if err := RegisterSerializable[serHealth](cur, "health"); err != nil {
+		t.Fatal(err)
+	}
+	a2 := cur.Add()
+	Set(cur, a2, serHealth{HP: 10})
+	cur.Remove(a2)
+	recycled := cur.Add() // reoccupies a2's index at the next generation
+	Set(cur, recycled, serHealth{HP: 42})
+
+	if a.index() != recycled.index() {
+		t.Fatalf("test setup assumption broken: a.index()=%d recycled.index()=%d", a.index(), recycled.index())
+	}
+
+	delta, err := Diff(prev, cur)
+	if err != nil {
+		t.Fatalf("diff: %v", err)
+	}
+	if err := Apply(prev, delta); err != nil {
+		t.Fatalf("apply: %v", err)
+	}
+
+	if IsAlive(prev, a) {
+		t.Fatal("stale handle to the removed occupant must not be alive after Apply")
+	}
+
+	replicaRecycled := newEntity(recycled.index(), recycled.generation())
+	if !IsAlive(prev, replicaRecycled) {
+		t.Fatal("the recycled entity should be alive after Apply")
+	}
+	hp, ok := Get[serHealth](prev, replicaRecycled)
+	if !ok || hp.HP != 42 {
+		t.Fatalf("Get(recycled) = %v, %v, want 42, true", hp, ok)
+	}
+}
@@ -0,0 +1,189 @@
+package ecs
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+)
+
+// System is a named unit of per-tick logic registered with an ECS via
+// RegisterSystem. Reads and writes declare which component types the
+// system's function touches, so that Tick can tell which systems may run
+// concurrently.
+type System struct {
+	name   string
+	reads  []reflect.Type
+	writes []reflect.Type
+	fn     func(*ECS)
+}
+
+func touchesType(types []reflect.Type, t reflect.Type) bool {
+	for _, typ := range types {
+		if typ == t {
+			return true
+		}
+	}
+
+	return false
+}
+
+// allComponentsMarker backs AllComponents; it's never instantiated as an
+// actual component.
+type allComponentsMarker struct{}
+
+// AllComponents is a sentinel component type that conflicts with every other
+// type and therefore every other system. Declare it in writes for any
+// system whose fn calls Remove or Unset: those touch every pool the target
+// entity actually has, not just whatever types the system otherwise reads
+// or writes, so there's no fixed, truthful read/write set to declare for
+// them. A system that writes AllComponents is placed in a stage of its own,
+// serialized against every other registered system.
+var AllComponents = reflect.TypeOf(allComponentsMarker{})
+
+// conflicts reports whether a and b access a common component type in a way
+// that requires them to run one after the other: either both write it, one
+// writes what the other reads, or either writes AllComponents (see
+// AllComponents).
+func conflicts(a, b *System) bool {
+	if touchesType(a.writes, AllComponents) || touchesType(b.writes, AllComponents) {
+		return true
+	}
+
+	for _, t := range a.writes {
+		if touchesType(b.writes, t) || touchesType(b.reads, t) {
+			return true
+		}
+	}
+
+	for _, t := range a.reads {
+		if touchesType(b.writes, t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RegisterSystem registers a system under the given name, declaring which
+// component types fn reads and writes. Registering a system immediately
+// recomputes the stages used by Tick: systems whose reads and writes don't
+// conflict with one another are grouped into the same stage and run
+// concurrently, while conflicting systems are placed into separate stages
+// and run one after the other. Each system goes into the earliest stage
+// holding nothing it conflicts with, so a system can run in an earlier stage
+// than a system it conflicts with that was registered before it.
+//
+// RegisterSystem reports an error, without registering the system, if a
+// system with the same name is already registered.
+//
+// If fn calls Remove or Unset, include AllComponents in writes (see
+// AllComponents) so Tick doesn't schedule it alongside a system that writes
+// some other component type the removal also touches.
+func RegisterSystem(e *ECS, name string, reads, writes []reflect.Type, fn func(*ECS)) error {
+	for _, s := range e.systems {
+		if s.name == name {
+			return fmt.Errorf("ecs: system %q is already registered", name)
+		}
+	}
+
+	e.systems = append(e.systems, &System{
+		name:   name,
+		reads:  reads,
+		writes: writes,
+		fn:     fn,
+	})
+
+	e.stages = stageSystems(e.systems)
+	return nil
+}
+
+// stageSystems assigns each system to the earliest stage in which it
+// conflicts with no system already placed there, preserving registration
+// order within a stage. Stage order is not registration order: a later
+// system lands in an earlier stage whenever it conflicts with nothing
+// already placed there.
+//
+// conflicts is a symmetric, data-only relation (a shared write, or one
+// system's write against another's read) rather than a directed "must run
+// before" edge, so there's no graph here for a cycle to form in: placement
+// never has to satisfy an ordering constraint between two systems, only
+// "don't share a stage with a system you conflict with". That's why
+// RegisterSystem never reports a cycle error. Conflicts themselves are not
+// silent, though: Stages lets a caller see exactly which systems Tick ended
+// up serializing against one another.
+func stageSystems(systems []*System) [][]*System {
+	var stages [][]*System
+
+	for _, s := range systems {
+		placed := false
+		for i := range stages {
+			conflict := false
+			for _, other := range stages[i] {
+				if conflicts(s, other) {
+					conflict = true
+					break
+				}
+			}
+
+			if !conflict {
+				stages[i] = append(stages[i], s)
+				placed = true
+				break
+			}
+		}
+
+		if !placed {
+			stages = append(stages, []*System{s})
+		}
+	}
+
+	return stages
+}
+
+// Stages returns the names of the registered systems, grouped into the
+// stages Tick runs them in, in execution order. Systems listed in the same
+// stage run concurrently; a system only appears in a later stage than
+// another because it conflicts (see conflicts) with at least one system in
+// every earlier stage. Callers can use this to inspect or log which
+// systems ended up serialized against each other.
+func (e *ECS) Stages() [][]string {
+	stages := make([][]string, len(e.stages))
+	for i, stage := range e.stages {
+		names := make([]string, len(stage))
+		for j, s := range stage {
+			names[j] = s.name
+		}
+		stages[i] = names
+	}
+
+	return stages
+}
+
+// Tick runs every registered system once. Systems grouped into the same
+// stage run concurrently in a worker pool; stages run in order, each
+// waiting for the previous one to finish.
+//
+// The concurrency this gives two systems in the same stage is only as safe
+// as their declared reads and writes are truthful. Remove and Unset are a
+// trap here: they touch every pool the target entity has, which generally
+// isn't the system's declared write set. A system that calls either must
+// declare AllComponents among its writes, or it can race against any other
+// concurrently scheduled system touching that entity's other components.
+func (e *ECS) Tick() {
+	for _, stage := range e.stages {
+		if len(stage) == 1 {
+			stage[0].fn(e)
+			continue
+		}
+
+		var wg sync.WaitGroup
+		wg.Add(len(stage))
+		for _, s := range stage {
+			go func(s *System) {
+				defer wg.Done()
+				s.fn(e)
+			}(s)
+		}
+		wg.Wait()
+	}
+}
@@ -0,0 +1,91 @@
+package ecs
+
+import (
+	"reflect"
+	"sync"
+	"testing"
+)
+
+type sysPos struct{ X int }
+type sysVel struct{ X int }
+
+func typeOf[T any]() reflect.Type {
+	var t T
+	return reflect.TypeOf(t)
+}
+
+func TestRegisterSystemRejectsDuplicateName(t *testing.T) {
+	e := New()
+
+	if err := RegisterSystem(e, "move", nil, nil, func(*ECS) {}); err != nil {
+		t.Fatalf("first registration: %v", err)
+	}
+
+	if err := RegisterSystem(e, "move", nil, nil, func(*ECS) {}); err == nil {
+		t.Fatal("expected error registering a duplicate system name")
+	}
+}
+
+func TestTickStagesDisjointSystemsTogether(t *testing.T) {
+	e := New()
+
+	if err := RegisterSystem(e, "a", nil, []reflect.Type{typeOf[sysPos]()}, func(*ECS) {}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterSystem(e, "b", nil, []reflect.Type{typeOf[sysVel]()}, func(*ECS) {}); err != nil {
+		t.Fatal(err)
+	}
+
+	stages := e.Stages()
+	if len(stages) != 1 || len(stages[0]) != 2 {
+		t.Fatalf("stages = %v, want both systems in a single stage", stages)
+	}
+}
+
+func TestTickSerializesConflictingSystems(t *testing.T) {
+	e := New()
+
+	if err := RegisterSystem(e, "a", nil, []reflect.Type{typeOf[sysPos]()}, func(*ECS) {}); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterSystem(e, "b", []reflect.Type{typeOf[sysPos]()}, nil, func(*ECS) {}); err != nil {
+		t.Fatal(err)
+	}
+
+	stages := e.Stages()
+	if len(stages) != 2 || len(stages[0]) != 1 || len(stages[1]) != 1 {
+		t.Fatalf("stages = %v, want two single-system stages", stages)
+	}
+}
+
+func TestTickRunsEveryRegisteredSystem(t *testing.T) {
+	e := New()
+
+	var mu sync.Mutex
+	ran := map[string]bool{}
+	mark := func(name string) func(*ECS) {
+		return func(*ECS) {
+			mu.Lock()
+			ran[name] = true
+			mu.Unlock()
+		}
+	}
+
+	if err := RegisterSystem(e, "a", nil, []reflect.Type{typeOf[sysPos]()}, mark("a")); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterSystem(e, "b", nil, []reflect.Type{typeOf[sysVel]()}, mark("b")); err != nil {
+		t.Fatal(err)
+	}
+	if err := RegisterSystem(e, "c", []reflect.Type{typeOf[sysPos]()}, nil, mark("c")); err != nil {
+		t.Fatal(err)
+	}
+
+	e.Tick()
+
+	for _, name := range []string{"a", "b", "c"} {
+		if !ran[name] {
+			t.Fatalf("system %q did not run", name)
+		}
+	}
+}
@@ -0,0 +1,340 @@
+package ecs
+
+import (
+	"sort"
+	"sync"
+)
+
+// viewEntry is one entity tracked by a view, ordered first by key and then
+// by entity index to keep the order stable when keys tie.
+type viewEntry struct {
+	entity Entity
+	key    int
+}
+
+// viewCore maintains a sorted, incrementally updated set of entities. It has
+// no notion of which component types an entity must have or how its key is
+// computed; View, View2, and View3 wrap it and keep it up to date from their
+// own OnSet/OnRemove observers.
+//
+// Those observers can fire from goroutines running concurrently: Tick runs
+// systems with disjoint write sets in a worker pool, and two such systems
+// can each write a component this view watches at the same time. mu guards
+// entries/index against that race.
+type viewCore struct {
+	mu      sync.Mutex
+	entries []viewEntry
+	index   map[int]int // entity index -> position in entries
+}
+
+func newViewCore() *viewCore {
+	return &viewCore{index: map[int]int{}}
+}
+
+// upsert inserts entity at the position implied by key, moving it there if
+// it's already present under a different key. It's a no-op if entity is
+// already present under the same key.
+func (c *viewCore) upsert(entity Entity, key int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.upsertLocked(entity, key)
+}
+
+// upsertLocked is upsert's body; the caller must hold mu.
+func (c *viewCore) upsertLocked(entity Entity, key int) {
+	index := entity.index()
+
+	if i, ok := c.index[index]; ok {
+		if c.entries[i].key == key && c.entries[i].entity == entity {
+			return
+		}
+
+		c.removeLocked(entity)
+	}
+
+	i := sort.Search(len(c.entries), func(i int) bool {
+		if c.entries[i].key != key {
+			return c.entries[i].key > key
+		}
+
+		return c.entries[i].entity.index() > index
+	})
+
+	c.entries = append(c.entries, viewEntry{})
+	copy(c.entries[i+1:], c.entries[i:])
+	c.entries[i] = viewEntry{entity: entity, key: key}
+
+	for j := i; j < len(c.entries); j++ {
+		c.index[c.entries[j].entity.index()] = j
+	}
+}
+
+func (c *viewCore) remove(entity Entity) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.removeLocked(entity)
+}
+
+func (c *viewCore) removeLocked(entity Entity) {
+	index := entity.index()
+
+	i, ok := c.index[index]
+	if !ok {
+		return
+	}
+
+	c.entries = append(c.entries[:i], c.entries[i+1:]...)
+	delete(c.index, index)
+
+	for j := i; j < len(c.entries); j++ {
+		c.index[c.entries[j].entity.index()] = j
+	}
+}
+
+func (c *viewCore) entities() []Entity {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entities := make([]Entity, len(c.entries))
+	for i, entry := range c.entries {
+		entities[i] = entry.entity
+	}
+
+	return entities
+}
+
+func (c *viewCore) len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return len(c.entries)
+}
+
+// viewOptions holds the configuration built up by a view's options.
+type viewOptions[A any] struct {
+	keyFn func(*A) int
+}
+
+// ViewOption configures a view over entities whose first component type is
+// A, as returned by NewView, NewView2, and NewView3.
+type ViewOption[A any] func(*viewOptions[A])
+
+// SortBy orders a view's entities by the int key extracted from their A
+// component, ascending. Without SortBy, a view is ordered by entity index.
+func SortBy[A any](key func(*A) int) ViewOption[A] {
+	return func(o *viewOptions[A]) {
+		o.keyFn = key
+	}
+}
+
+// View is a cached, sorted query over every entity with an A component. It's
+// kept in sync with e via OnSet/OnRemove observers as components are added,
+// changed, and removed, so that Range doesn't need to rescan A's pool.
+type View[A any] struct {
+	e     *ECS
+	keyFn func(*A) int
+	core  *viewCore
+}
+
+// NewView builds a View over every entity currently in e with an A
+// component, and keeps it in sync as e changes.
+func NewView[A any](e *ECS, opts ...ViewOption[A]) *View[A] {
+	var cfg viewOptions[A]
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	v := &View[A]{e: e, keyFn: cfg.keyFn, core: newViewCore()}
+
+	for iterator := Iterate[A](e); ; {
+		entity, _, ok := iterator.Next()
+		if !ok {
+			break
+		}
+
+		v.refresh(e, entity)
+	}
+
+	OnSet(e, func(e *ECS, entity Entity, _ *A) { v.refresh(e, entity) })
+	OnRemove[A](e, v.refresh)
+
+	return v
+}
+
+func (v *View[A]) refresh(e *ECS, entity Entity) {
+	a, ok := Get[A](v.e, entity)
+	if !ok {
+		v.core.remove(entity)
+		return
+	}
+
+	key := entity.index()
+	if v.keyFn != nil {
+		key = v.keyFn(a)
+	}
+
+	v.core.upsert(entity, key)
+}
+
+// Range calls fn for every entity in the view, in order. Unlike Iterate,
+// Range only looks up the entities the view already knows match, instead of
+// rescanning the whole pool.
+func (v *View[A]) Range(fn func(entity Entity, a *A)) {
+	for _, entity := range v.core.entities() {
+		a, ok := Get[A](v.e, entity)
+		if !ok {
+			continue
+		}
+
+		fn(entity, a)
+	}
+}
+
+// Len returns the number of entities currently in the view.
+func (v *View[A]) Len() int {
+	return v.core.len()
+}
+
+// View2 is the 2-component form of View, backed by Join[A, B] instead of
+// Iterate[A]. Entities are ordered by the key extracted from their A
+// component.
+type View2[A, B any] struct {
+	e     *ECS
+	keyFn func(*A) int
+	core  *viewCore
+}
+
+// NewView2 builds a View2 over every entity currently in e with both an A
+// and a B component, and keeps it in sync as e changes.
+func NewView2[A, B any](e *ECS, opts ...ViewOption[A]) *View2[A, B] {
+	var cfg viewOptions[A]
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	v := &View2[A, B]{e: e, keyFn: cfg.keyFn, core: newViewCore()}
+
+	for iterator := Join[A, B](e); ; {
+		entity, _, _, ok := iterator.Next()
+		if !ok {
+			break
+		}
+
+		v.refresh(e, entity)
+	}
+
+	OnSet(e, func(e *ECS, entity Entity, _ *A) { v.refresh(e, entity) })
+	OnRemove[A](e, v.refresh)
+	OnSet(e, func(e *ECS, entity Entity, _ *B) { v.refresh(e, entity) })
+	OnRemove[B](e, v.refresh)
+
+	return v
+}
+
+func (v *View2[A, B]) refresh(e *ECS, entity Entity) {
+	a, _, ok := Get2[A, B](v.e, entity)
+	if !ok {
+		v.core.remove(entity)
+		return
+	}
+
+	key := entity.index()
+	if v.keyFn != nil {
+		key = v.keyFn(a)
+	}
+
+	v.core.upsert(entity, key)
+}
+
+// Range calls fn for every entity in the view, in order. Unlike Join, Range
+// only looks up the entities the view already knows match, instead of
+// rescanning both pools.
+func (v *View2[A, B]) Range(fn func(entity Entity, a *A, b *B)) {
+	for _, entity := range v.core.entities() {
+		a, b, ok := Get2[A, B](v.e, entity)
+		if !ok {
+			continue
+		}
+
+		fn(entity, a, b)
+	}
+}
+
+// Len returns the number of entities currently in the view.
+func (v *View2[A, B]) Len() int {
+	return v.core.len()
+}
+
+// View3 is the 3-component form of View, backed by Join3[A, B, C] instead of
+// Iterate[A]. Entities are ordered by the key extracted from their A
+// component.
+type View3[A, B, C any] struct {
+	e     *ECS
+	keyFn func(*A) int
+	core  *viewCore
+}
+
+// NewView3 builds a View3 over every entity currently in e with an A, a B,
+// and a C component, and keeps it in sync as e changes.
+func NewView3[A, B, C any](e *ECS, opts ...ViewOption[A]) *View3[A, B, C] {
+	var cfg viewOptions[A]
+	for _, opt := range opts {
+		opt(&cfg)
+	}
+
+	v := &View3[A, B, C]{e: e, keyFn: cfg.keyFn, core: newViewCore()}
+
+	for iterator := Join3[A, B, C](e); ; {
+		entity, _, _, _, ok := iterator.Next()
+		if !ok {
+			break
+		}
+
+		v.refresh(e, entity)
+	}
+
+	OnSet(e, func(e *ECS, entity Entity, _ *A) { v.refresh(e, entity) })
+	OnRemove[A](e, v.refresh)
+	OnSet(e, func(e *ECS, entity Entity, _ *B) { v.refresh(e, entity) })
+	OnRemove[B](e, v.refresh)
+	OnSet(e, func(e *ECS, entity Entity, _ *C) { v.refresh(e, entity) })
+	OnRemove[C](e, v.refresh)
+
+	return v
+}
+
+func (v *View3[A, B, C]) refresh(e *ECS, entity Entity) {
+	a, _, _, ok := Get3[A, B, C](v.e, entity)
+	if !ok {
+		v.core.remove(entity)
+		return
+	}
+
+	key := entity.index()
+	if v.keyFn != nil {
+		key = v.keyFn(a)
+	}
+
+	v.core.upsert(entity, key)
+}
+
+// Range calls fn for every entity in the view, in order. Unlike Join3, Range
+// only looks up the entities the view already knows match, instead of
+// rescanning all three pools.
+func (v *View3[A, B, C]) Range(fn func(entity Entity, a *A, b *B, c *C)) {
+	for _, entity := range v.core.entities() {
+		a, b, c, ok := Get3[A, B, C](v.e, entity)
+		if !ok {
+			continue
+		}
+
+		fn(entity, a, b, c)
+	}
+}
+
+// Len returns the number of entities currently in the view.
+func (v *View3[A, B, C]) Len() int {
+	return v.core.len()
+}